@@ -0,0 +1,119 @@
+package faq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Answerer synthesizes a natural-language answer to a query from a set of
+// candidate FAQs, typically via a chat completion model, citing which FAQ
+// IDs it drew from.
+type Answerer interface {
+	Answer(ctx context.Context, query string, candidates []FAQ) (AnswerResult, error)
+}
+
+// NewAnswererFromEnv selects an Answerer based on the ANSWER_PROVIDER
+// environment variable ("openai"). It returns a nil Answerer (not an error)
+// when ANSWER_PROVIDER is unset, so callers can treat answer synthesis as an
+// optional stage.
+func NewAnswererFromEnv() (Answerer, error) {
+	switch provider := strings.ToLower(os.Getenv("ANSWER_PROVIDER")); provider {
+	case "":
+		return nil, nil
+	case "openai":
+		return NewOpenAIAnswerer(), nil
+	default:
+		return nil, fmt.Errorf("unknown ANSWER_PROVIDER: %s", provider)
+	}
+}
+
+// OpenAIAnswerer synthesizes answers with an OpenAI chat completion model.
+type OpenAIAnswerer struct {
+	apiKey   string
+	endpoint string
+	model    string
+}
+
+// NewOpenAIAnswerer builds an OpenAIAnswerer from OPENAI_API_KEY,
+// OPENAI_CHAT_URL, and OPENAI_ANSWER_MODEL.
+func NewOpenAIAnswerer() *OpenAIAnswerer {
+	return &OpenAIAnswerer{
+		apiKey:   os.Getenv("OPENAI_API_KEY"),
+		endpoint: envOrDefault("OPENAI_CHAT_URL", "https://api.openai.com/v1/chat/completions"),
+		model:    envOrDefault("OPENAI_ANSWER_MODEL", "gpt-4o-mini"),
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model          string            `json:"model"`
+	Messages       []chatMessage     `json:"messages"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// answerSystemPrompt instructs the model to ground its answer in the
+// supplied candidates and to report which ones it actually used, so callers
+// get verifiable citations rather than a free-floating answer.
+const answerSystemPrompt = `You answer questions using only the provided FAQ candidates. Respond with JSON of the form {"answer": "...", "citations": [<FAQ ids you used>]}. Cite only IDs that directly support your answer.`
+
+func (a *OpenAIAnswerer) Answer(ctx context.Context, query string, candidates []FAQ) (AnswerResult, error) {
+	var sb strings.Builder
+	for _, c := range candidates {
+		fmt.Fprintf(&sb, "FAQ %d:\nQ: %s\nA: %s\n\n", c.ID, c.Question, c.Answer)
+	}
+	userPrompt := fmt.Sprintf("Candidates:\n%s\nQuestion: %s", sb.String(), query)
+
+	reqBody, _ := json.Marshal(chatCompletionRequest{
+		Model: a.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: answerSystemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", a.endpoint, bytes.NewBuffer(reqBody))
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return AnswerResult{}, fmt.Errorf("answer request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return AnswerResult{}, fmt.Errorf("answer API error: %v - %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var parsed chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return AnswerResult{}, fmt.Errorf("error decoding answer response: %v", err)
+	}
+	if len(parsed.Choices) == 0 {
+		return AnswerResult{}, fmt.Errorf("answer API returned no choices")
+	}
+
+	var result AnswerResult
+	if err := json.Unmarshal([]byte(parsed.Choices[0].Message.Content), &result); err != nil {
+		return AnswerResult{}, fmt.Errorf("error parsing answer JSON: %v", err)
+	}
+	return result, nil
+}
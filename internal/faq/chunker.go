@@ -0,0 +1,153 @@
+package faq
+
+import (
+	"strings"
+)
+
+// defaultChunkSize and defaultChunkOverlap are approximated in runes rather
+// than whitespace-split "words", since languages like Japanese (the repo's
+// primary content language — see the Japanese log/prompt strings throughout
+// this package) have no spaces between words.
+const (
+	defaultChunkSize    = 512
+	defaultChunkOverlap = 64
+)
+
+// ChunkParams configures a Chunker's size and overlap, in approximate
+// tokens (runes). Zero values fall back to defaultChunkSize/defaultChunkOverlap.
+type ChunkParams struct {
+	Size    int
+	Overlap int
+}
+
+// Chunker splits document text into overlapping windows of at most Size
+// runes. Paragraphs are split into sentences and whole sentences are packed
+// into each window, so chunks break between sentences (every paragraph end
+// is also a sentence end; paragraph ends are not preferred over other
+// sentence ends). A sentence is hard-cut only when it doesn't fit in a
+// window alongside the overlap carried over from the previous chunk.
+type Chunker struct {
+	size    int
+	overlap int
+}
+
+// NewChunker builds a Chunker from params, applying defaults for zero
+// values and clamping an overlap that's >= size.
+func NewChunker(params ChunkParams) *Chunker {
+	size := params.Size
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	overlap := params.Overlap
+	if overlap <= 0 {
+		overlap = defaultChunkOverlap
+	}
+	if overlap >= size {
+		overlap = size / 4
+	}
+	return &Chunker{size: size, overlap: overlap}
+}
+
+// Chunk splits text into chunks, dropping any that are empty after
+// whitespace trimming. Windowing operates on runes, not on
+// strings.Fields-style "words", so it works for non-whitespace-delimited
+// text (Japanese, Chinese, ...) as well as space-delimited text.
+func (c *Chunker) Chunk(text string) []string {
+	var chunks []string
+	var window []rune
+	added := 0 // runes appended to window since the last flush
+
+	flush := func() {
+		if joined := strings.TrimSpace(string(window)); joined != "" {
+			chunks = append(chunks, joined)
+		}
+	}
+
+	appendRunes := func(runes []rune) {
+		for _, r := range runes {
+			if len(window) >= c.size {
+				flush()
+				window = overlapTail(window, c.overlap)
+				added = 0
+			}
+			window = append(window, r)
+			added++
+		}
+	}
+
+	for _, paragraph := range splitParagraphs(text) {
+		for _, sentence := range splitSentences(paragraph) {
+			runes := []rune(sentence + " ")
+			// Close the current chunk at this sentence boundary if the
+			// sentence would overflow it, rather than cutting the sentence.
+			if added > 0 && len(window)+len(runes) > c.size {
+				flush()
+				window = overlapTail(window, c.overlap)
+				added = 0
+			}
+			appendRunes(runes)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// overlapTail returns the last n runes of window, used to seed the next
+// chunk so consecutive chunks share context.
+func overlapTail(window []rune, n int) []rune {
+	if n <= 0 || n >= len(window) {
+		return append([]rune(nil), window...)
+	}
+	tail := window[len(window)-n:]
+	return append([]rune(nil), tail...)
+}
+
+// splitParagraphs splits text on blank lines.
+func splitParagraphs(text string) []string {
+	raw := strings.Split(text, "\n\n")
+	paragraphs := make([]string, 0, len(raw))
+	for _, p := range raw {
+		if strings.TrimSpace(p) != "" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return paragraphs
+}
+
+// sentenceEnders are sentence-final punctuation marks, both ASCII
+// ('.', '!', '?') and the Japanese full-width equivalents ('。', '！',
+// '？'), the latter of which aren't followed by whitespace.
+var sentenceEnders = map[rune]bool{
+	'.': true, '!': true, '?': true,
+	'。': true, '！': true, '？': true,
+}
+
+// asciiSentenceEnders require trailing whitespace to count as a sentence
+// break, since a bare ASCII '.'/'!'/'?' is ambiguous (e.g. abbreviations,
+// decimal numbers) in a way the Japanese full-width enders aren't.
+var asciiSentenceEnders = map[rune]bool{'.': true, '!': true, '?': true}
+
+// splitSentences splits a paragraph into sentences on sentence-final
+// punctuation. It's a heuristic, not a full sentence tokenizer.
+func splitSentences(paragraph string) []string {
+	runes := []rune(paragraph)
+	var sentences []string
+	start := 0
+	for i, r := range runes {
+		if !sentenceEnders[r] {
+			continue
+		}
+		if asciiSentenceEnders[r] {
+			if i+1 >= len(runes) || (runes[i+1] != ' ' && runes[i+1] != '\n') {
+				continue
+			}
+		}
+		sentences = append(sentences, string(runes[start:i+1]))
+		start = i + 1
+	}
+	if rest := strings.TrimSpace(string(runes[start:])); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
@@ -0,0 +1,81 @@
+package faq
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkerJapaneseText(t *testing.T) {
+	// Japanese has no spaces between words, so a whitespace-based chunker
+	// would see the whole document as a single "word" and never window.
+	sentence := "これはテストの文章です。日本語にはスペースがありません。"
+	text := strings.Repeat(sentence, 100)
+	runeCount := utf8.RuneCountInString(text)
+
+	c := NewChunker(ChunkParams{Size: 50, Overlap: 10})
+	chunks := c.Chunk(text)
+
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks for %d-rune Japanese text with Size=50, got %d", runeCount, len(chunks))
+	}
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > 50 {
+			t.Errorf("chunk %d has %d runes, want at most Size (50)", i, n)
+		}
+	}
+}
+
+func TestOverlapTail(t *testing.T) {
+	window := []rune("abcdefghij")
+
+	if got := string(overlapTail(window, 3)); got != "hij" {
+		t.Errorf("overlapTail(_, 3) = %q, want %q", got, "hij")
+	}
+	if got := string(overlapTail(window, 0)); got != "abcdefghij" {
+		t.Errorf("overlapTail(_, 0) = %q, want the full window", got)
+	}
+	if got := string(overlapTail(window, 100)); got != "abcdefghij" {
+		t.Errorf("overlapTail(_, 100) (n > len(window)) = %q, want the full window", got)
+	}
+}
+
+func TestChunkerOverlap(t *testing.T) {
+	text := strings.Repeat("word ", 200)
+	c := NewChunker(ChunkParams{Size: 50, Overlap: 20})
+	chunks := c.Chunk(text)
+
+	if len(chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(chunks))
+	}
+
+	// Overlapping windows duplicate ~Overlap runes at each chunk boundary,
+	// so the chunks' combined length should exceed the source text's, but
+	// not by more than roughly Overlap runes per boundary.
+	totalRunes := 0
+	for _, chunk := range chunks {
+		totalRunes += utf8.RuneCountInString(chunk)
+	}
+	textRunes := utf8.RuneCountInString(strings.TrimSpace(text))
+	if totalRunes <= textRunes {
+		t.Errorf("total chunk length (%d runes) should exceed the source text's (%d) if chunks overlap", totalRunes, textRunes)
+	}
+	if maxExpected := textRunes + 20*len(chunks); totalRunes > maxExpected {
+		t.Errorf("total chunk length (%d runes) is more than expected even accounting for overlap (max ~%d)", totalRunes, maxExpected)
+	}
+}
+
+func TestChunkerDropsEmptyInput(t *testing.T) {
+	c := NewChunker(ChunkParams{Size: 50, Overlap: 10})
+	if chunks := c.Chunk("   \n\n  "); len(chunks) != 0 {
+		t.Errorf("expected no chunks for blank input, got %v", chunks)
+	}
+}
+
+func TestChunkerSmallTextIsOneChunk(t *testing.T) {
+	c := NewChunker(ChunkParams{Size: 512, Overlap: 64})
+	chunks := c.Chunk("Short text that fits in a single chunk.")
+	if len(chunks) != 1 {
+		t.Fatalf("expected 1 chunk, got %d: %v", len(chunks), chunks)
+	}
+}
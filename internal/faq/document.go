@@ -0,0 +1,206 @@
+package faq
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pgvector/pgvector-go"
+)
+
+// Chunk is a slice of a Document's text, embedded and stored independently
+// so search can retrieve the most relevant passage rather than a whole
+// document.
+type Chunk struct {
+	ID    int64          `json:"id,omitempty"`
+	DocID int64          `json:"doc_id"`
+	Index int            `json:"chunk_index"`
+	Text  string         `json:"text"`
+	Meta  map[string]any `json:"metadata,omitempty"`
+}
+
+// ChunkResult is a Chunk matched against a query, along with its parent
+// document's path and metadata.
+type ChunkResult struct {
+	Chunk
+	DocPath     string         `json:"doc_path"`
+	DocMetadata map[string]any `json:"doc_metadata,omitempty"`
+	Distance    float64        `json:"distance,omitempty"`
+}
+
+// IngestDocument walks root recursively and ingests every file whose base
+// name matches glob (e.g. "*.md"): each file is chunked with s.chunker,
+// embedded in one batch, and upserted keyed by a content hash, so
+// re-running IngestDocument over unchanged files is a no-op.
+func (s *QueryService) IngestDocument(root, glob string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		matched, err := filepath.Match(glob, d.Name())
+		if err != nil {
+			return fmt.Errorf("invalid glob %q: %v", glob, err)
+		}
+		if !matched {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+		if err := s.ingestOneDocument(path, string(content)); err != nil {
+			return fmt.Errorf("error ingesting %s: %v", path, err)
+		}
+		return nil
+	})
+}
+
+// ingestOneDocument upserts path's document row keyed by a SHA-256 of its
+// content, then (re)chunks, embeds, and upserts its chunks. Unchanged
+// content is skipped entirely.
+func (s *QueryService) ingestOneDocument(path, content string) error {
+	ctx := context.Background()
+	hash := sha256Hex(content)
+	metadata, _ := json.Marshal(map[string]string{"ext": filepath.Ext(path)})
+
+	var docID int64
+	var existingHash string
+	err := s.pool.QueryRow(ctx, `SELECT id, content_hash FROM documents WHERE path = $1`, path).Scan(&docID, &existingHash)
+	switch {
+	case err == nil:
+		if existingHash == hash {
+			log.Printf("ドキュメント変更なし。スキップ: %s", path)
+			return nil
+		}
+		// path was ingested before under different content: update the
+		// row in place and drop its stale chunks rather than leaving them
+		// behind for search to keep returning.
+		if _, err := s.pool.Exec(ctx, `
+			UPDATE documents SET content_hash = $1, metadata = $2 WHERE id = $3
+		`, hash, metadata, docID); err != nil {
+			return fmt.Errorf("error updating document: %v", err)
+		}
+		if _, err := s.pool.Exec(ctx, `DELETE FROM chunks WHERE doc_id = $1`, docID); err != nil {
+			return fmt.Errorf("error clearing stale chunks: %v", err)
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		// content_hash is UNIQUE, so a file at a new path but with content
+		// identical to an already-ingested document would collide here. Treat
+		// that as a duplicate of the existing document rather than stealing
+		// its row by flipping path to this one: documents can only have one
+		// path each, and aliasing back and forth between duplicate-content
+		// paths on repeated re-ingests isn't idempotent.
+		var existingPath string
+		switch err := s.pool.QueryRow(ctx, `SELECT path FROM documents WHERE content_hash = $1`, hash).Scan(&existingPath); {
+		case err == nil:
+			log.Printf("内容が重複しています。スキップ: %s (既存: %s)", path, existingPath)
+			return nil
+		case errors.Is(err, pgx.ErrNoRows):
+			if err := s.pool.QueryRow(ctx, `
+				INSERT INTO documents (path, content_hash, metadata)
+				VALUES ($1, $2, $3)
+				RETURNING id
+			`, path, hash, metadata).Scan(&docID); err != nil {
+				return fmt.Errorf("error inserting document: %v", err)
+			}
+		default:
+			return fmt.Errorf("error checking for duplicate content: %v", err)
+		}
+	default:
+		return fmt.Errorf("error looking up document: %v", err)
+	}
+
+	chunks := s.chunker.Chunk(content)
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	embeddings, err := s.embedder.Embed(ctx, chunks)
+	if err != nil {
+		return fmt.Errorf("embedding generation failed: %v", err)
+	}
+
+	batch := &pgx.Batch{}
+	for i, text := range chunks {
+		batch.Queue(`
+			INSERT INTO chunks (doc_id, chunk_index, text, embedding, provider, model, dimension, tsv)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, to_tsvector($8::regconfig, $3))
+			ON CONFLICT (doc_id, chunk_index) DO UPDATE
+			SET text = EXCLUDED.text, embedding = EXCLUDED.embedding, tsv = EXCLUDED.tsv
+		`, docID, i, text, pgvector.NewVector(embeddings[i]),
+			s.embedder.Provider(), s.embedder.Model(), len(embeddings[i]), s.ftsConfig)
+	}
+
+	br := s.pool.SendBatch(ctx, batch)
+	defer br.Close()
+	for range chunks {
+		if _, err := br.Exec(); err != nil {
+			return fmt.Errorf("error inserting chunk: %v", err)
+		}
+	}
+
+	log.Printf("ドキュメント登録完了: %s (%d chunks)", path, len(chunks))
+	return nil
+}
+
+// SearchChunks ranks document chunks by pgvector embedding similarity,
+// analogous to searchDense but over the chunks/documents tables, and
+// returns each chunk alongside its parent document's path and metadata.
+func (s *QueryService) SearchChunks(query string, k int) ([]ChunkResult, error) {
+	ctx := context.Background()
+
+	embeddings, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embedding generation failed: %v", err)
+	}
+	vectorEmbedding := pgvector.NewVector(embeddings[0])
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT c.id, c.doc_id, c.chunk_index, c.text, d.path, d.metadata,
+		       c.embedding <-> $1 AS distance
+		FROM chunks c
+		JOIN documents d ON d.id = c.doc_id
+		WHERE c.provider = $2 AND c.model = $3
+		ORDER BY c.embedding <-> $1
+		LIMIT $4
+	`, vectorEmbedding, s.embedder.Provider(), s.embedder.Model(), k)
+	if err != nil {
+		return nil, fmt.Errorf("chunk search query failed: %v", err)
+	}
+	defer rows.Close()
+
+	var results []ChunkResult
+	for rows.Next() {
+		var r ChunkResult
+		var docMetadata []byte
+		if err := rows.Scan(&r.ID, &r.DocID, &r.Index, &r.Text, &r.DocPath, &docMetadata, &r.Distance); err != nil {
+			return nil, fmt.Errorf("error scanning chunk search result: %v", err)
+		}
+		if len(docMetadata) > 0 {
+			if err := json.Unmarshal(docMetadata, &r.DocMetadata); err != nil {
+				return nil, fmt.Errorf("error decoding document metadata: %v", err)
+			}
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,223 @@
+package faq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Embedder turns text into embedding vectors. Implementations identify
+// themselves via Provider/Model so rows can be tagged with the embedding
+// space they were produced in.
+type Embedder interface {
+	// Embed embeds inputs in a single call where the provider allows it,
+	// returning one vector per input in the same order.
+	Embed(ctx context.Context, inputs []string) ([][]float32, error)
+	// Provider is a short identifier for the embedding backend, e.g.
+	// "openai", "azure", "ollama".
+	Provider() string
+	// Model is the embedding model name used by this Embedder.
+	Model() string
+}
+
+// NewEmbedderFromEnv selects an Embedder based on the EMBED_PROVIDER
+// environment variable ("openai", "azure", or "ollama"; defaults to
+// "openai").
+func NewEmbedderFromEnv() (Embedder, error) {
+	switch provider := strings.ToLower(os.Getenv("EMBED_PROVIDER")); provider {
+	case "", "openai":
+		return NewOpenAIEmbedder(), nil
+	case "azure":
+		return NewAzureEmbedder(), nil
+	case "ollama":
+		return NewOllamaEmbedder(), nil
+	default:
+		return nil, fmt.Errorf("unknown EMBED_PROVIDER: %s", provider)
+	}
+}
+
+func envOrDefault(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+type embeddingRequest struct {
+	Input []string `json:"input"`
+	Model string   `json:"model"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// OpenAIEmbedder calls OpenAI's embeddings endpoint.
+type OpenAIEmbedder struct {
+	apiKey   string
+	endpoint string
+	model    string
+}
+
+// NewOpenAIEmbedder builds an OpenAIEmbedder from OPENAI_API_KEY and API_URL.
+func NewOpenAIEmbedder() *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		apiKey:   os.Getenv("OPENAI_API_KEY"),
+		endpoint: os.Getenv("API_URL"),
+		model:    envOrDefault("OPENAI_EMBED_MODEL", "text-embedding-ada-002"),
+	}
+}
+
+func (e *OpenAIEmbedder) Provider() string { return "openai" }
+func (e *OpenAIEmbedder) Model() string    { return e.model }
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	reqBody, _ := json.Marshal(embeddingRequest{
+		Input: inputs,
+		Model: e.model,
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", e.endpoint, bytes.NewBuffer(reqBody))
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doEmbeddingRequest(req, len(inputs))
+}
+
+// AzureEmbedder calls an Azure OpenAI embeddings deployment, which uses a
+// differently-shaped URL (endpoint + deployment + api-version) and an
+// "api-key" header instead of a bearer token.
+type AzureEmbedder struct {
+	apiKey     string
+	endpoint   string
+	deployment string
+	apiVersion string
+	model      string
+}
+
+// NewAzureEmbedder builds an AzureEmbedder from AZURE_OPENAI_API_KEY,
+// AZURE_OPENAI_ENDPOINT, AZURE_OPENAI_DEPLOYMENT, AZURE_OPENAI_API_VERSION,
+// and AZURE_OPENAI_MODEL.
+func NewAzureEmbedder() *AzureEmbedder {
+	return &AzureEmbedder{
+		apiKey:     os.Getenv("AZURE_OPENAI_API_KEY"),
+		endpoint:   os.Getenv("AZURE_OPENAI_ENDPOINT"),
+		deployment: os.Getenv("AZURE_OPENAI_DEPLOYMENT"),
+		apiVersion: envOrDefault("AZURE_OPENAI_API_VERSION", "2023-05-15"),
+		model:      envOrDefault("AZURE_OPENAI_MODEL", "text-embedding-ada-002"),
+	}
+}
+
+func (e *AzureEmbedder) Provider() string { return "azure" }
+func (e *AzureEmbedder) Model() string    { return e.model }
+
+func (e *AzureEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	reqBody, _ := json.Marshal(embeddingRequest{
+		Input: inputs,
+		Model: e.model,
+	})
+
+	url := fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s",
+		strings.TrimRight(e.endpoint, "/"), e.deployment, e.apiVersion)
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	req.Header.Set("api-key", e.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	return doEmbeddingRequest(req, len(inputs))
+}
+
+func doEmbeddingRequest(req *http.Request, wantCount int) ([][]float32, error) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("リクエストエラー: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("APIエラー: %v - %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var parsed embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("レスポンスのデコードエラー: %v", err)
+	}
+
+	if len(parsed.Data) != wantCount {
+		return nil, fmt.Errorf("Embeddingデータの件数が不正です: got %d, want %d", len(parsed.Data), wantCount)
+	}
+
+	embeddings := make([][]float32, wantCount)
+	for i, d := range parsed.Data {
+		embeddings[i] = d.Embedding
+	}
+	return embeddings, nil
+}
+
+// OllamaEmbedder calls a local Ollama server's /api/embeddings endpoint.
+// Unlike OpenAI/Azure, Ollama embeds one prompt per request, so Embed issues
+// inputs sequentially.
+type OllamaEmbedder struct {
+	endpoint string
+	model    string
+}
+
+// NewOllamaEmbedder builds an OllamaEmbedder from OLLAMA_URL and
+// OLLAMA_MODEL.
+func NewOllamaEmbedder() *OllamaEmbedder {
+	return &OllamaEmbedder{
+		endpoint: envOrDefault("OLLAMA_URL", "http://localhost:11434"),
+		model:    envOrDefault("OLLAMA_MODEL", "nomic-embed-text"),
+	}
+}
+
+func (e *OllamaEmbedder) Provider() string { return "ollama" }
+func (e *OllamaEmbedder) Model() string    { return e.model }
+
+type ollamaEmbeddingRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type ollamaEmbeddingResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+func (e *OllamaEmbedder) Embed(ctx context.Context, inputs []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(inputs))
+	for i, input := range inputs {
+		reqBody, _ := json.Marshal(ollamaEmbeddingRequest{Model: e.model, Prompt: input})
+
+		req, _ := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(e.endpoint, "/")+"/api/embeddings", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("リクエストエラー: %v", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("APIエラー: %v - %s", resp.StatusCode, string(bodyBytes))
+		}
+
+		var parsed ollamaEmbeddingResponse
+		err = json.NewDecoder(resp.Body).Decode(&parsed)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("レスポンスのデコードエラー: %v", err)
+		}
+
+		embeddings[i] = parsed.Embedding
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,178 @@
+package faq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// RerankedCandidate is a FAQ candidate scored by a Reranker. Score is
+// larger-is-better and is not comparable across Reranker implementations.
+type RerankedCandidate struct {
+	FAQ
+	Score float64
+}
+
+// Reranker re-scores a set of candidate FAQs against a query, typically
+// using a cross-encoder that's more accurate (but slower) than embedding
+// similarity.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, candidates []FAQ) ([]RerankedCandidate, error)
+}
+
+// NewRerankerFromEnv selects a Reranker based on the RERANK_PROVIDER
+// environment variable ("cohere" or "bge"). It returns a nil Reranker (not
+// an error) when RERANK_PROVIDER is unset, so callers can treat reranking
+// as an optional stage.
+func NewRerankerFromEnv() (Reranker, error) {
+	switch provider := strings.ToLower(os.Getenv("RERANK_PROVIDER")); provider {
+	case "":
+		return nil, nil
+	case "cohere":
+		return NewCohereReranker(), nil
+	case "bge":
+		return NewBGEReranker(), nil
+	default:
+		return nil, fmt.Errorf("unknown RERANK_PROVIDER: %s", provider)
+	}
+}
+
+// CohereReranker calls Cohere's Rerank API.
+type CohereReranker struct {
+	apiKey   string
+	endpoint string
+	model    string
+}
+
+// NewCohereReranker builds a CohereReranker from COHERE_API_KEY,
+// COHERE_RERANK_URL, and COHERE_RERANK_MODEL.
+func NewCohereReranker() *CohereReranker {
+	return &CohereReranker{
+		apiKey:   os.Getenv("COHERE_API_KEY"),
+		endpoint: envOrDefault("COHERE_RERANK_URL", "https://api.cohere.com/v1/rerank"),
+		model:    envOrDefault("COHERE_RERANK_MODEL", "rerank-english-v3.0"),
+	}
+}
+
+type cohereRerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+}
+
+type cohereRerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func (r *CohereReranker) Rerank(ctx context.Context, query string, candidates []FAQ) ([]RerankedCandidate, error) {
+	documents := make([]string, len(candidates))
+	for i, c := range candidates {
+		documents[i] = c.Question + "\n" + c.Answer
+	}
+
+	reqBody, _ := json.Marshal(cohereRerankRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: documents,
+	})
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", r.endpoint, bytes.NewBuffer(reqBody))
+	req.Header.Set("Authorization", "Bearer "+r.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("rerank API error: %v - %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var parsed cohereRerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("error decoding rerank response: %v", err)
+	}
+
+	reranked := make([]RerankedCandidate, len(parsed.Results))
+	for i, res := range parsed.Results {
+		if res.Index < 0 || res.Index >= len(candidates) {
+			return nil, fmt.Errorf("rerank response index %d out of range for %d candidates", res.Index, len(candidates))
+		}
+		reranked[i] = RerankedCandidate{FAQ: candidates[res.Index], Score: res.RelevanceScore}
+	}
+	sortRerankedByScoreDesc(reranked)
+	return reranked, nil
+}
+
+// BGEReranker calls a self-hosted BGE cross-encoder reranking endpoint.
+type BGEReranker struct {
+	endpoint string
+}
+
+// NewBGEReranker builds a BGEReranker from BGE_RERANK_URL.
+func NewBGEReranker() *BGEReranker {
+	return &BGEReranker{endpoint: envOrDefault("BGE_RERANK_URL", "http://localhost:8001/rerank")}
+}
+
+type bgeRerankRequest struct {
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+}
+
+type bgeRerankResponse struct {
+	Scores []float64 `json:"scores"`
+}
+
+func (r *BGEReranker) Rerank(ctx context.Context, query string, candidates []FAQ) ([]RerankedCandidate, error) {
+	documents := make([]string, len(candidates))
+	for i, c := range candidates {
+		documents[i] = c.Question + "\n" + c.Answer
+	}
+
+	reqBody, _ := json.Marshal(bgeRerankRequest{Query: query, Documents: documents})
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", r.endpoint, bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("rerank API error: %v - %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var parsed bgeRerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("error decoding rerank response: %v", err)
+	}
+	if len(parsed.Scores) != len(candidates) {
+		return nil, fmt.Errorf("rerank scores count mismatch: got %d, want %d", len(parsed.Scores), len(candidates))
+	}
+
+	reranked := make([]RerankedCandidate, len(candidates))
+	for i, c := range candidates {
+		reranked[i] = RerankedCandidate{FAQ: c, Score: parsed.Scores[i]}
+	}
+	sortRerankedByScoreDesc(reranked)
+	return reranked, nil
+}
+
+func sortRerankedByScoreDesc(reranked []RerankedCandidate) {
+	sort.Slice(reranked, func(i, j int) bool { return reranked[i].Score > reranked[j].Score })
+}
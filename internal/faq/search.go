@@ -0,0 +1,276 @@
+package faq
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/pgvector/pgvector-go"
+
+	"rag-app/internal/retrieval"
+)
+
+// rrfK is the Reciprocal Rank Fusion smoothing constant. 60 is the
+// commonly-cited default from the original RRF paper.
+const rrfK = 60
+
+const (
+	defaultDenseWeight  = 1.0
+	defaultSparseWeight = 1.0
+)
+
+// defaultCandidateK is how many candidates AnswerFAQ retrieves before
+// reranking/synthesis, when RAGParams.CandidateK is unset.
+const defaultCandidateK = 20
+
+// SearchParams configures a FAQ search.
+type SearchParams struct {
+	Query string
+	K     int
+	Mode  retrieval.Mode
+
+	// DenseWeight and SparseWeight scale each source's contribution to the
+	// fused score in retrieval.Hybrid mode. Zero means "use the default
+	// weight of 1".
+	DenseWeight  float64
+	SparseWeight float64
+}
+
+// Search returns FAQs matching params.Query, ranked according to
+// params.Mode.
+func (s *QueryService) Search(params SearchParams) ([]SearchResult, error) {
+	switch params.Mode {
+	case retrieval.Sparse:
+		return s.searchSparse(context.Background(), params.Query, params.K)
+	case retrieval.Hybrid:
+		return s.searchHybrid(context.Background(), params)
+	default:
+		return s.searchDense(context.Background(), params.Query, params.K)
+	}
+}
+
+// SearchFAQ embeds query and returns the k closest FAQs via dense
+// (embedding) search, ordered from closest to farthest.
+func (s *QueryService) SearchFAQ(query string, k int) ([]SearchResult, error) {
+	return s.searchDense(context.Background(), query, k)
+}
+
+// searchDense ranks FAQs by pgvector embedding similarity. Only rows
+// embedded with the same provider and model as the query are considered,
+// since distances across embedding spaces aren't comparable.
+func (s *QueryService) searchDense(ctx context.Context, query string, k int) ([]SearchResult, error) {
+	embeddings, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		log.Printf("Embedding生成エラー: %v", err)
+		return nil, err
+	}
+
+	vectorEmbedding := pgvector.NewVector(embeddings[0])
+
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, question, answer, embedding <-> $1 AS distance
+		FROM faqs
+		WHERE provider = $2 AND model = $3
+		ORDER BY embedding <-> $1
+		LIMIT $4
+	`, vectorEmbedding, s.embedder.Provider(), s.embedder.Model(), k)
+	if err != nil {
+		return nil, fmt.Errorf("dense search query failed: %v", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.ID, &r.Question, &r.Answer, &r.Distance); err != nil {
+			return nil, fmt.Errorf("error scanning dense search result: %v", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// searchSparse ranks FAQs by PostgreSQL full-text search over their tsv
+// column.
+func (s *QueryService) searchSparse(ctx context.Context, query string, k int) ([]SearchResult, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, question, answer, ts_rank_cd(tsv, plainto_tsquery($3::regconfig, $1)) AS score
+		FROM faqs
+		WHERE tsv @@ plainto_tsquery($3::regconfig, $1)
+		ORDER BY score DESC
+		LIMIT $2
+	`, query, k, s.ftsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("sparse search query failed: %v", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.ID, &r.Question, &r.Answer, &r.Score); err != nil {
+			return nil, fmt.Errorf("error scanning sparse search result: %v", err)
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// searchHybrid runs dense and sparse search in parallel and fuses their
+// rankings with Reciprocal Rank Fusion.
+func (s *QueryService) searchHybrid(ctx context.Context, params SearchParams) ([]SearchResult, error) {
+	denseWeight := params.DenseWeight
+	if denseWeight == 0 {
+		denseWeight = defaultDenseWeight
+	}
+	sparseWeight := params.SparseWeight
+	if sparseWeight == 0 {
+		sparseWeight = defaultSparseWeight
+	}
+
+	var dense, sparse []SearchResult
+	var denseErr, sparseErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dense, denseErr = s.searchDense(ctx, params.Query, params.K)
+	}()
+	sparse, sparseErr = s.searchSparse(ctx, params.Query, params.K)
+	<-done
+
+	if denseErr != nil {
+		return nil, denseErr
+	}
+	if sparseErr != nil {
+		return nil, sparseErr
+	}
+
+	return fuseRRF(params.K, rankedList{results: dense, weight: denseWeight}, rankedList{results: sparse, weight: sparseWeight}), nil
+}
+
+// RAGParams configures the retrieve -> rerank -> synthesize pipeline behind
+// AnswerFAQ.
+type RAGParams struct {
+	Query string
+	Mode  retrieval.Mode
+
+	// CandidateK is how many candidates are fetched in the retrieval stage,
+	// before reranking and synthesis narrow them down. Zero means
+	// defaultCandidateK.
+	CandidateK int
+	// K is how many candidates survive into the final answer's citations.
+	// Zero means 1.
+	K int
+
+	DenseWeight  float64
+	SparseWeight float64
+}
+
+// AnswerResult is the structured output of AnswerFAQ: a synthesized answer
+// plus the FAQ IDs it's grounded in.
+type AnswerResult struct {
+	Answer    string  `json:"answer"`
+	Citations []int64 `json:"citations"`
+}
+
+// AnswerFAQ runs the full RAG loop: retrieve params.CandidateK candidates,
+// optionally rerank them with a cross-encoder (if a Reranker was configured
+// via WithReranker), keep the top params.K, and optionally synthesize a
+// cited answer from them with a chat completion model (if an Answerer was
+// configured via WithAnswerer). With neither configured, it falls back to
+// returning the top candidate's answer verbatim, so callers can run
+// vector-only, vector+rerank, or full RAG depending on what's wired up.
+func (s *QueryService) AnswerFAQ(params RAGParams) (*AnswerResult, error) {
+	ctx := context.Background()
+
+	candidateK := params.CandidateK
+	if candidateK == 0 {
+		candidateK = defaultCandidateK
+	}
+	k := params.K
+	if k == 0 {
+		k = 1
+	}
+
+	results, err := s.Search(SearchParams{
+		Query:        params.Query,
+		K:            candidateK,
+		Mode:         params.Mode,
+		DenseWeight:  params.DenseWeight,
+		SparseWeight: params.SparseWeight,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no matching FAQs found")
+	}
+
+	candidates := make([]FAQ, len(results))
+	for i, r := range results {
+		candidates[i] = r.FAQ
+	}
+
+	if s.reranker != nil {
+		reranked, err := s.reranker.Rerank(ctx, params.Query, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("rerank failed: %v", err)
+		}
+		candidates = candidates[:0]
+		for _, rc := range reranked {
+			candidates = append(candidates, rc.FAQ)
+		}
+	}
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	if s.answerer != nil {
+		result, err := s.answerer.Answer(ctx, params.Query, candidates)
+		if err != nil {
+			return nil, fmt.Errorf("answer synthesis failed: %v", err)
+		}
+		return &result, nil
+	}
+
+	citations := make([]int64, len(candidates))
+	for i, c := range candidates {
+		citations[i] = c.ID
+	}
+	return &AnswerResult{Answer: candidates[0].Answer, Citations: citations}, nil
+}
+
+type rankedList struct {
+	results []SearchResult
+	weight  float64
+}
+
+// fuseRRF merges ranked lists with weighted Reciprocal Rank Fusion:
+// score(d) = Σ weight_i / (rrfK + rank_i(d) + 1), and returns the top k
+// documents by fused score.
+func fuseRRF(k int, lists ...rankedList) []SearchResult {
+	scores := make(map[int64]float64)
+	faqs := make(map[int64]FAQ)
+
+	for _, list := range lists {
+		for rank, r := range list.results {
+			scores[r.ID] += list.weight / float64(rrfK+rank+1)
+			faqs[r.ID] = r.FAQ
+		}
+	}
+
+	fused := make([]SearchResult, 0, len(scores))
+	for id, score := range scores {
+		fused = append(fused, SearchResult{FAQ: faqs[id], Score: score})
+	}
+
+	sort.Slice(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+
+	if len(fused) > k {
+		fused = fused[:k]
+	}
+	return fused
+}
@@ -0,0 +1,79 @@
+package faq
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFuseRRFOrdersByFusedScore(t *testing.T) {
+	dense := rankedList{
+		results: []SearchResult{
+			{FAQ: FAQ{ID: 1}},
+			{FAQ: FAQ{ID: 2}},
+			{FAQ: FAQ{ID: 3}},
+		},
+		weight: 1,
+	}
+	sparse := rankedList{
+		results: []SearchResult{
+			{FAQ: FAQ{ID: 3}},
+			{FAQ: FAQ{ID: 1}},
+			{FAQ: FAQ{ID: 4}},
+		},
+		weight: 1,
+	}
+
+	fused := fuseRRF(10, dense, sparse)
+
+	// ID 1 is rank 0 in dense and rank 1 in sparse: the best combined rank
+	// of any document, so it should come out on top.
+	if len(fused) == 0 || fused[0].ID != 1 {
+		t.Fatalf("expected ID 1 to rank first, got %+v", fused)
+	}
+
+	wantScore := 1/float64(rrfK+0+1) + 1/float64(rrfK+1+1)
+	if math.Abs(fused[0].Score-wantScore) > 1e-9 {
+		t.Errorf("fused[0].Score = %v, want %v", fused[0].Score, wantScore)
+	}
+}
+
+func TestFuseRRFRespectsWeight(t *testing.T) {
+	dense := rankedList{
+		results: []SearchResult{{FAQ: FAQ{ID: 1}}},
+		weight:  2,
+	}
+	sparse := rankedList{
+		results: []SearchResult{{FAQ: FAQ{ID: 2}}},
+		weight:  1,
+	}
+
+	fused := fuseRRF(10, dense, sparse)
+	if len(fused) != 2 || fused[0].ID != 1 {
+		t.Fatalf("expected ID 1 (higher weight) to rank first, got %+v", fused)
+	}
+}
+
+func TestFuseRRFTruncatesToK(t *testing.T) {
+	list := rankedList{
+		results: []SearchResult{
+			{FAQ: FAQ{ID: 1}},
+			{FAQ: FAQ{ID: 2}},
+			{FAQ: FAQ{ID: 3}},
+		},
+		weight: 1,
+	}
+
+	if fused := fuseRRF(2, list); len(fused) != 2 {
+		t.Fatalf("expected fuseRRF to truncate to k=2, got %d results", len(fused))
+	}
+}
+
+func TestFuseRRFDedupesAcrossLists(t *testing.T) {
+	dense := rankedList{results: []SearchResult{{FAQ: FAQ{ID: 1}}}, weight: 1}
+	sparse := rankedList{results: []SearchResult{{FAQ: FAQ{ID: 1}}}, weight: 1}
+
+	fused := fuseRRF(10, dense, sparse)
+	if len(fused) != 1 {
+		t.Fatalf("expected a document appearing in both lists to be deduped to one result, got %d", len(fused))
+	}
+}
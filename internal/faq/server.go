@@ -0,0 +1,325 @@
+package faq
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"rag-app/internal/retrieval"
+)
+
+const defaultSearchK = 5
+
+// Server exposes a QueryService over HTTP/JSON.
+type Server struct {
+	svc *QueryService
+
+	// docsRoot jails POST /documents: the request's root is resolved
+	// relative to it and rejected if it would escape, so an unauthenticated
+	// caller can't point the directory ingester at arbitrary paths on disk
+	// (e.g. "/etc" or "../../"). Empty disables the endpoint entirely.
+	docsRoot string
+}
+
+// NewServer creates a Server backed by svc. docsRoot is the directory POST
+// /documents is allowed to ingest from; pass "" to disable that endpoint.
+func NewServer(svc *QueryService, docsRoot string) *Server {
+	return &Server{svc: svc, docsRoot: docsRoot}
+}
+
+// Handler returns the http.Handler serving the FAQ API:
+//
+//	POST /faqs                            bulk/single FAQ insert
+//	POST /documents                       ingest a directory of documents, chunked (jailed to docsRoot)
+//	GET  /search?q=&k=&mode=&dense_weight=&sparse_weight=  top-K FAQ search
+//	GET  /chunks?q=&k=                     top-K document chunk search
+//	GET  /answer?q=&k=&candidate_k=&mode=&dense_weight=&sparse_weight=  RAG answer synthesis
+//	GET  /health                          liveness check
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("/faqs", s.handleFAQs)
+	mux.HandleFunc("/documents", s.handleDocuments)
+	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/chunks", s.handleChunks)
+	mux.HandleFunc("/answer", s.handleAnswer)
+	return mux
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleFAQs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	faqs, err := decodeFAQs(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.svc.InsertFAQs(faqs); err != nil {
+		http.Error(w, fmt.Sprintf("failed to insert FAQs: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"inserted": len(faqs)})
+}
+
+// decodeFAQs accepts either a single {question, answer} object or a JSON
+// array of them, so callers can insert one FAQ or many in a single request.
+func decodeFAQs(r *http.Request) ([]FAQ, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var faqs []FAQ
+	if err := json.Unmarshal(body, &faqs); err == nil {
+		return faqs, nil
+	}
+
+	var faq FAQ
+	if err := json.Unmarshal(body, &faq); err != nil {
+		return nil, err
+	}
+	return []FAQ{faq}, nil
+}
+
+// documentsRequest is the body of a POST /documents request: root, resolved
+// relative to the Server's docsRoot jail, is walked recursively and every
+// file matching glob is ingested.
+type documentsRequest struct {
+	Root string `json:"root"`
+	Glob string `json:"glob"`
+}
+
+func (s *Server) handleDocuments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req documentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Glob == "" {
+		req.Glob = "*"
+	}
+
+	root, err := s.resolveIngestRoot(req.Root)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.svc.IngestDocument(root, req.Glob); err != nil {
+		http.Error(w, fmt.Sprintf("ingest failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ingested"})
+}
+
+// resolveIngestRoot resolves reqRoot against s.docsRoot (an absolute reqRoot
+// is treated as relative to the jail) and rejects ".."-style escapes, so a
+// POST /documents caller can only ingest from inside the configured jail,
+// not arbitrary paths on the host.
+func (s *Server) resolveIngestRoot(reqRoot string) (string, error) {
+	if s.docsRoot == "" {
+		return "", fmt.Errorf("document ingestion is disabled: server has no docsRoot configured")
+	}
+
+	jail, err := filepath.Abs(s.docsRoot)
+	if err != nil {
+		return "", fmt.Errorf("invalid docsRoot: %v", err)
+	}
+
+	// filepath.Join treats an "absolute" reqRoot (e.g. "/etc") as just
+	// another path segment rather than special-casing it, so this folds
+	// both absolute paths and relative ones into the same jail check below.
+	resolved := filepath.Clean(filepath.Join(jail, reqRoot))
+	if resolved != jail && !strings.HasPrefix(resolved, jail+string(filepath.Separator)) {
+		return "", fmt.Errorf("root %q escapes the configured ingest directory", reqRoot)
+	}
+	return resolved, nil
+}
+
+func (s *Server) handleChunks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "missing required query parameter: q", http.StatusBadRequest)
+		return
+	}
+
+	k, err := parseIntParam(r, "k", defaultSearchK)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.svc.SearchChunks(query, k)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("chunk search failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "missing required query parameter: q", http.StatusBadRequest)
+		return
+	}
+
+	k, err := parseIntParam(r, "k", defaultSearchK)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mode, err := retrieval.ParseMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	denseWeight, err := parseFloatParam(r, "dense_weight")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sparseWeight, err := parseFloatParam(r, "sparse_weight")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.svc.Search(SearchParams{
+		Query:        query,
+		K:            k,
+		Mode:         mode,
+		DenseWeight:  denseWeight,
+		SparseWeight: sparseWeight,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("search failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// handleAnswer runs the full RAG loop (retrieve, optionally rerank,
+// optionally synthesize) and returns the resulting AnswerResult. Which
+// stages actually run depends on how the Server's QueryService was
+// constructed (see WithReranker/WithAnswerer).
+func (s *Server) handleAnswer(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "missing required query parameter: q", http.StatusBadRequest)
+		return
+	}
+
+	k, err := parseIntParam(r, "k", 1)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	candidateK, err := parseIntParam(r, "candidate_k", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	mode, err := retrieval.ParseMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	denseWeight, err := parseFloatParam(r, "dense_weight")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sparseWeight, err := parseFloatParam(r, "sparse_weight")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.svc.AnswerFAQ(RAGParams{
+		Query:        query,
+		Mode:         mode,
+		K:            k,
+		CandidateK:   candidateK,
+		DenseWeight:  denseWeight,
+		SparseWeight: sparseWeight,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("answer failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// parseIntParam parses the name query parameter as a positive integer,
+// returning def if it's absent.
+func parseIntParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil || parsed <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return parsed, nil
+}
+
+func parseFloatParam(r *http.Request, name string) (float64, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return parsed, nil
+}
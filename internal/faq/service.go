@@ -0,0 +1,300 @@
+// Package faq contains the embedding + pgvector search logic shared by the
+// CLI and HTTP entry points.
+package faq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pgvector/pgvector-go"
+)
+
+// defaultBatchSize and defaultConcurrency bound how many FAQs are embedded
+// per provider call and how many batches run at once when ingesting, unless
+// overridden via WithBatchSize/WithConcurrency.
+const (
+	defaultBatchSize   = 100
+	defaultConcurrency = 4
+)
+
+// defaultFTSConfig is the Postgres text search configuration used to build
+// and query the tsv columns. "simple" lowercases and strips stopwords but
+// doesn't meaningfully segment CJK text, so sparse/hybrid search ranks
+// Japanese content poorly out of the box — this repo's primary content
+// language. For better Japanese segmentation, install pg_bigm, register it
+// as a text search configuration (e.g. `CREATE TEXT SEARCH CONFIGURATION
+// bigm (COPY = simple); ALTER TEXT SEARCH CONFIGURATION bigm ALTER MAPPING
+// FOR default WITH bigm;`), and set FTS_CONFIG=bigm via WithFTSConfig. This
+// isn't the default because pg_bigm isn't guaranteed to be installed.
+const defaultFTSConfig = "simple"
+
+// FAQ is a single question/answer pair. ID is 0 for FAQs that haven't been
+// loaded from the database (e.g. freshly parsed from faqs.json).
+type FAQ struct {
+	ID       int64  `json:"id,omitempty"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// SearchResult is a FAQ matched against a query. Distance (smaller is
+// closer) is populated in retrieval.Dense mode; Score (larger is better) is
+// populated in retrieval.Sparse and retrieval.Hybrid mode.
+type SearchResult struct {
+	FAQ
+	Distance float64 `json:"distance,omitempty"`
+	Score    float64 `json:"score,omitempty"`
+}
+
+// QueryService wraps the embedding + pgvector search logic against a
+// connection pool so both the CLI and the HTTP server can share it.
+type QueryService struct {
+	pool        *pgxpool.Pool
+	embedder    Embedder
+	batchSize   int
+	concurrency int
+
+	// reranker and answerer are optional; when nil, AnswerFAQ skips that
+	// stage of the RAG pipeline.
+	reranker Reranker
+	answerer Answerer
+
+	// chunker splits document text for IngestDocument.
+	chunker *Chunker
+
+	// ftsConfig is the Postgres text search configuration used for tsv
+	// generation and querying. See defaultFTSConfig.
+	ftsConfig string
+}
+
+// Option configures a QueryService.
+type Option func(*QueryService)
+
+// WithBatchSize sets how many FAQs are embedded per Embed call during
+// ingestion.
+func WithBatchSize(n int) Option {
+	return func(s *QueryService) {
+		if n > 0 {
+			s.batchSize = n
+		}
+	}
+}
+
+// WithConcurrency sets how many embedding batches are processed at once
+// during ingestion.
+func WithConcurrency(n int) Option {
+	return func(s *QueryService) {
+		if n > 0 {
+			s.concurrency = n
+		}
+	}
+}
+
+// WithReranker enables the rerank stage of AnswerFAQ's RAG pipeline.
+func WithReranker(r Reranker) Option {
+	return func(s *QueryService) {
+		s.reranker = r
+	}
+}
+
+// WithAnswerer enables the answer-synthesis stage of AnswerFAQ's RAG
+// pipeline.
+func WithAnswerer(a Answerer) Option {
+	return func(s *QueryService) {
+		s.answerer = a
+	}
+}
+
+// WithChunkParams configures the size/overlap of the Chunker IngestDocument
+// splits documents with.
+func WithChunkParams(params ChunkParams) Option {
+	return func(s *QueryService) {
+		s.chunker = NewChunker(params)
+	}
+}
+
+// WithFTSConfig sets the Postgres text search configuration used for tsv
+// generation and querying (see defaultFTSConfig). An empty name is ignored.
+func WithFTSConfig(name string) Option {
+	return func(s *QueryService) {
+		if name != "" {
+			s.ftsConfig = name
+		}
+	}
+}
+
+// NewQueryService creates a QueryService backed by pool, embedding text via
+// embedder.
+func NewQueryService(pool *pgxpool.Pool, embedder Embedder, opts ...Option) *QueryService {
+	s := &QueryService{
+		pool:        pool,
+		embedder:    embedder,
+		batchSize:   defaultBatchSize,
+		concurrency: defaultConcurrency,
+		chunker:     NewChunker(ChunkParams{}),
+		ftsConfig:   defaultFTSConfig,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// InsertFAQ embeds question and stores it, along with answer, in the faqs
+// table.
+func (s *QueryService) InsertFAQ(question, answer string) error {
+	return s.insertBatch([]FAQ{{Question: question, Answer: answer}})
+}
+
+// InsertFAQs embeds and inserts faqs in batches of s.batchSize, with up to
+// s.concurrency batches in flight at once. Callers posting many FAQs in one
+// request should use this instead of calling InsertFAQ per row, which would
+// cost one embedder round-trip per FAQ.
+func (s *QueryService) InsertFAQs(faqs []FAQ) error {
+	return s.insertBatches(faqs)
+}
+
+// insertBatch embeds and inserts faqs together: one embedder call for all
+// their questions, then one pgx.Batch round-trip to insert them all. Each
+// row is tagged with the embedder's provider, model, and the embedding's
+// dimension, so rows embedded by different providers/models can coexist —
+// unless the 0003_vector_index migration has been applied, which narrows
+// the embedding column to a single fixed dimension (see IndexParams in
+// internal/migrate/migrate.go).
+func (s *QueryService) insertBatch(faqs []FAQ) error {
+	questions := make([]string, len(faqs))
+	for i, faq := range faqs {
+		questions[i] = faq.Question
+	}
+
+	embeddings, err := s.embedder.Embed(context.Background(), questions)
+	if err != nil {
+		log.Printf("Embedding生成エラー: %v", err)
+		return fmt.Errorf("embedding generation failed: %v", err)
+	}
+
+	batch := &pgx.Batch{}
+	for i, faq := range faqs {
+		batch.Queue(`
+			INSERT INTO faqs (question, answer, embedding, provider, model, dimension, tsv)
+			VALUES ($1, $2, $3, $4, $5, $6, to_tsvector($7::regconfig, $1 || ' ' || $2))
+		`, faq.Question, faq.Answer, pgvector.NewVector(embeddings[i]),
+			s.embedder.Provider(), s.embedder.Model(), len(embeddings[i]), s.ftsConfig)
+	}
+
+	br := s.pool.SendBatch(context.Background(), batch)
+	defer br.Close()
+	for range faqs {
+		if _, err := br.Exec(); err != nil {
+			log.Printf("FAQデータの登録エラー: %v", err)
+			return err
+		}
+	}
+
+	log.Printf("FAQデータ登録完了: %d件", len(faqs))
+	return nil
+}
+
+// insertBatches splits faqs into s.batchSize-sized groups and runs
+// insertBatch over them, with up to s.concurrency groups in flight at once.
+// Shared by InsertFAQs and IngestFile so both bulk-insert paths fan out the
+// same way.
+func (s *QueryService) insertBatches(faqs []FAQ) error {
+	sem := make(chan struct{}, s.concurrency)
+	errCh := make(chan error, (len(faqs)+s.batchSize-1)/max(1, s.batchSize))
+	var wg sync.WaitGroup
+
+	for start := 0; start < len(faqs); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(faqs) {
+			end = len(faqs)
+		}
+		batch := faqs[start:end]
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(batch []FAQ) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := s.insertBatch(batch); err != nil {
+				errCh <- fmt.Errorf("error inserting FAQ batch: %v", err)
+			}
+		}(batch)
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFAQsFromFile reads a JSON array of {question, answer} pairs from
+// filename.
+func LoadFAQsFromFile(filename string) ([]FAQ, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var faqs []FAQ
+	err = json.NewDecoder(file).Decode(&faqs)
+	return faqs, err
+}
+
+// ExistingQuestions returns the set of questions already stored in the faqs
+// table.
+func (s *QueryService) ExistingQuestions() (map[string]bool, error) {
+	rows, err := s.pool.Query(context.Background(), `SELECT question FROM faqs`)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to fetch existing questions: %v", err)
+	}
+	defer rows.Close()
+
+	existingQuestions := make(map[string]bool)
+	for rows.Next() {
+		var question string
+		if err := rows.Scan(&question); err != nil {
+			return nil, fmt.Errorf("Error scanning question: %v", err)
+		}
+		existingQuestions[question] = true
+	}
+
+	return existingQuestions, nil
+}
+
+// IngestFile loads FAQs from filename and inserts any that aren't already
+// present in the faqs table. New FAQs are embedded in batches of
+// s.batchSize, with up to s.concurrency batches in flight at once.
+func (s *QueryService) IngestFile(filename string) error {
+	existingQuestions, err := s.ExistingQuestions()
+	if err != nil {
+		return fmt.Errorf("error getting existing questions: %v", err)
+	}
+
+	faqs, err := LoadFAQsFromFile(filename)
+	if err != nil {
+		return fmt.Errorf("error loading FAQs: %v", err)
+	}
+
+	var newFAQs []FAQ
+	for _, faq := range faqs {
+		if _, exists := existingQuestions[faq.Question]; exists {
+			log.Printf("既存の質問です。スキップ: %s", faq.Question)
+			continue
+		}
+		newFAQs = append(newFAQs, faq)
+	}
+
+	return s.insertBatches(newFAQs)
+}
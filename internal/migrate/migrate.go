@@ -0,0 +1,322 @@
+// Package migrate applies numbered, embedded SQL migrations against the
+// app's Postgres database, tracking which have run in a schema_migrations
+// table. This mirrors the migration approach used by projects like
+// polycule-connect: plain up/down .sql files, applied in order inside a
+// transaction, rather than an ORM-driven schema DSL.
+package migrate
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed sql/*.sql
+var migrationFS embed.FS
+
+// IndexParams configures the tunable parameters of the vector index
+// migration (0003_vector_index): either HNSW's m/ef_construction or
+// IVFFlat's lists, depending on Type, plus the fixed Dimension the
+// faqs/chunks embedding columns are pinned to.
+//
+// faqs.embedding/chunks.embedding start out as a bare, dimension-less
+// vector column specifically so rows from different embedding
+// providers/models (tracked per-row in the dimension column, see
+// internal/faq/service.go) can coexist. hnsw/ivfflat indexes don't support
+// that: pgvector requires a fixed-dimension vector(N) column to build
+// either index type. 0003_vector_index therefore narrows the column to
+// vector(Dimension) before indexing it, trading away mixed-dimension
+// ingestion (inserting a row of a different dimension now fails at the
+// database level) for a usable index. Deployments that must keep mixing
+// embedding dimensions in one table should skip this migration rather than
+// run it against a dimension that doesn't match all their providers.
+type IndexParams struct {
+	// Type selects the index kind: "hnsw" (default) or "ivfflat".
+	Type           string
+	M              int
+	EfConstruction int
+	Lists          int
+	// Dimension is the fixed vector size faqs.embedding/chunks.embedding are
+	// narrowed to before indexing. Must match the embedding model actually
+	// in use (1536 for OpenAI's default text-embedding-ada-002).
+	Dimension int
+}
+
+// IndexParamsFromEnv reads VECTOR_INDEX_TYPE, VECTOR_INDEX_M,
+// VECTOR_INDEX_EF_CONSTRUCTION, VECTOR_INDEX_LISTS, and
+// VECTOR_INDEX_DIMENSION, defaulting to an HNSW index with m=16,
+// ef_construction=64 (IVFFlat's lists defaults to 100 when
+// VECTOR_INDEX_TYPE=ivfflat) pinned to dimension 1536.
+func IndexParamsFromEnv() IndexParams {
+	return IndexParams{
+		Type:           strings.ToLower(envOrDefault("VECTOR_INDEX_TYPE", "hnsw")),
+		M:              envIntOrDefault("VECTOR_INDEX_M", 16),
+		EfConstruction: envIntOrDefault("VECTOR_INDEX_EF_CONSTRUCTION", 64),
+		Lists:          envIntOrDefault("VECTOR_INDEX_LISTS", 100),
+		Dimension:      envIntOrDefault("VECTOR_INDEX_DIMENSION", 1536),
+	}
+}
+
+func envOrDefault(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envIntOrDefault(name string, fallback int) int {
+	if v := os.Getenv(name); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// migration is one numbered schema change, loaded from a <version>_<name>.up.sql
+// / <version>_<name>.down.sql pair under sql/.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// loadMigrations reads and pairs up every *.up.sql/*.down.sql file under
+// sql/, sorted by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := migrationFS.ReadDir("sql")
+	if err != nil {
+		return nil, fmt.Errorf("error reading embedded migrations: %v", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		version, rest, isUp := 0, "", false
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			rest, isUp = strings.TrimSuffix(name, ".up.sql"), true
+		case strings.HasSuffix(name, ".down.sql"):
+			rest, isUp = strings.TrimSuffix(name, ".down.sql"), false
+		default:
+			continue
+		}
+
+		versionStr, migName, found := strings.Cut(rest, "_")
+		if !found {
+			return nil, fmt.Errorf("malformed migration filename: %s", name)
+		}
+		version, err = strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("malformed migration version in filename %s: %v", name, err)
+		}
+
+		content, err := migrationFS.ReadFile("sql/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %v", name, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: migName}
+			byVersion[version] = m
+		}
+		if isUp {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+// Runner applies or rolls back migrations against a connection pool.
+type Runner struct {
+	pool   *pgxpool.Pool
+	params IndexParams
+}
+
+// NewRunner builds a Runner that renders index-tuning migrations with
+// params.
+func NewRunner(pool *pgxpool.Pool, params IndexParams) *Runner {
+	return &Runner{pool: pool, params: params}
+}
+
+// ensureSchemaMigrationsTable creates the bookkeeping table tracking which
+// migrations have been applied, if it doesn't already exist.
+func (r *Runner) ensureSchemaMigrationsTable(ctx context.Context) error {
+	_, err := r.pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	return err
+}
+
+// appliedVersions returns the set of migration versions recorded in
+// schema_migrations.
+func (r *Runner) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	rows, err := r.pool.Query(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// render executes sql as a text/template against r.params, so a migration
+// (currently only 0003_vector_index) can branch on the configured index
+// type and parameters. Migrations with no template directives render
+// unchanged.
+func (r *Runner) render(sql string) (string, error) {
+	tmpl, err := template.New("migration").Parse(sql)
+	if err != nil {
+		return "", fmt.Errorf("error parsing migration template: %v", err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, r.params); err != nil {
+		return "", fmt.Errorf("error rendering migration template: %v", err)
+	}
+	return sb.String(), nil
+}
+
+// Up applies every migration not yet recorded in schema_migrations, each in
+// its own transaction, in ascending version order.
+func (r *Runner) Up(ctx context.Context) error {
+	if err := r.ensureSchemaMigrationsTable(ctx); err != nil {
+		return fmt.Errorf("error ensuring schema_migrations table: %v", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	applied, err := r.appliedVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading applied migrations: %v", err)
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		sql, err := r.render(m.up)
+		if err != nil {
+			return fmt.Errorf("migration %d_%s: %v", m.version, m.name, err)
+		}
+
+		tx, err := r.pool.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("error beginning transaction for migration %d_%s: %v", m.version, m.name, err)
+		}
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("migration %d_%s failed: %v", m.version, m.name, err)
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+			tx.Rollback(ctx)
+			return fmt.Errorf("error recording migration %d_%s: %v", m.version, m.name, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("error committing migration %d_%s: %v", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// Down rolls back the single most recently applied migration.
+func (r *Runner) Down(ctx context.Context) error {
+	if err := r.ensureSchemaMigrationsTable(ctx); err != nil {
+		return fmt.Errorf("error ensuring schema_migrations table: %v", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	applied, err := r.appliedVersions(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading applied migrations: %v", err)
+	}
+
+	var target *migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if applied[migrations[i].version] {
+			target = &migrations[i]
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("no migrations to roll back")
+	}
+
+	sql, err := r.render(target.down)
+	if err != nil {
+		return fmt.Errorf("migration %d_%s: %v", target.version, target.name, err)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction for migration %d_%s: %v", target.version, target.name, err)
+	}
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("migration %d_%s rollback failed: %v", target.version, target.name, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, target.version); err != nil {
+		tx.Rollback(ctx)
+		return fmt.Errorf("error unrecording migration %d_%s: %v", target.version, target.name, err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Status returns the versions of all applied and pending migrations, in
+// ascending order.
+func (r *Runner) Status(ctx context.Context) (applied, pending []int, err error) {
+	if err := r.ensureSchemaMigrationsTable(ctx); err != nil {
+		return nil, nil, fmt.Errorf("error ensuring schema_migrations table: %v", err)
+	}
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, nil, err
+	}
+	appliedSet, err := r.appliedVersions(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading applied migrations: %v", err)
+	}
+
+	for _, m := range migrations {
+		if appliedSet[m.version] {
+			applied = append(applied, m.version)
+		} else {
+			pending = append(pending, m.version)
+		}
+	}
+	return applied, pending, nil
+}
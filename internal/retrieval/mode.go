@@ -0,0 +1,30 @@
+// Package retrieval defines the retrieval strategies the FAQ search
+// supports: dense (embedding similarity), sparse (full-text), or a hybrid
+// fusion of both.
+package retrieval
+
+import "fmt"
+
+// Mode selects how FAQ search ranks candidates.
+type Mode string
+
+const (
+	// Dense ranks by pgvector embedding similarity only.
+	Dense Mode = "dense"
+	// Sparse ranks by PostgreSQL full-text search only.
+	Sparse Mode = "sparse"
+	// Hybrid fuses dense and sparse rankings via Reciprocal Rank Fusion.
+	Hybrid Mode = "hybrid"
+)
+
+// ParseMode parses s into a Mode, defaulting to Dense for an empty string.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "":
+		return Dense, nil
+	case Dense, Sparse, Hybrid:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("unknown retrieval mode: %s", s)
+	}
+}
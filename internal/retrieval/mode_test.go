@@ -0,0 +1,34 @@
+package retrieval
+
+import "testing"
+
+func TestParseMode(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    Mode
+		wantErr bool
+	}{
+		{"", Dense, false},
+		{"dense", Dense, false},
+		{"sparse", Sparse, false},
+		{"hybrid", Hybrid, false},
+		{"bogus", "", true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseMode(tc.in)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseMode(%q) = %q, nil; want an error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMode(%q) returned unexpected error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseMode(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
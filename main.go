@@ -2,158 +2,123 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
-	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
-	"github.com/pgvector/pgvector-go"
+
+	"rag-app/internal/faq"
+	"rag-app/internal/migrate"
+	"rag-app/internal/retrieval"
 )
 
-type EmbeddingRequest struct {
-	Input string `json:"input"`
-	Model string `json:"model"`
+// envInt parses name as an int, returning 0 (which leaves the QueryService
+// default in place) if it's unset or invalid.
+func envInt(name string) int {
+	n, _ := strconv.Atoi(os.Getenv(name))
+	return n
 }
 
-type EmbeddingResponse struct {
-	Data []struct {
-		Embedding []float32 `json:"embedding"`
-	} `json:"data"`
+// postgresURL builds a connection string from the POSTGRES_* env vars
+// godotenv loads from .env.
+func postgresURL() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s", os.Getenv("POSTGRES_USER"),
+		os.Getenv("POSTGRES_PW"), os.Getenv("POSTGRES_HOST"), os.Getenv("POSTGRES_PORT"), os.Getenv("POSTGRES_DB"))
 }
 
-func getEmbedding(input string) ([]float32, error) {
-	openAIKey := os.Getenv("OPENAI_API_KEY")
-	openAIEndpoint := os.Getenv("API_URL")
-	reqBody, _ := json.Marshal(EmbeddingRequest{
-		Input: input,
-		Model: "text-embedding-ada-002",
-	})
-
-	req, _ := http.NewRequest("POST", openAIEndpoint, bytes.NewBuffer(reqBody))
-	req.Header.Set("Authorization", "Bearer "+openAIKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("リクエストエラー: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("APIエラー: %v - %s", resp.StatusCode, string(bodyBytes))
-	}
-
-	var embeddingResponse EmbeddingResponse
-	err = json.NewDecoder(resp.Body).Decode(&embeddingResponse)
-	if err != nil {
-		return nil, fmt.Errorf("レスポンスのデコードエラー: %v", err)
+func runCLI(svc *faq.QueryService, mode retrieval.Mode) {
+	if err := svc.IngestFile("faqs.json"); err != nil {
+		log.Fatalf("Error ingesting FAQs: %v", err)
 	}
 
-	// データが空かチェック
-	if len(embeddingResponse.Data) == 0 {
-		return nil, fmt.Errorf("Embeddingデータが返されていません")
-	}
+	fmt.Println("全てのFAQデータが登録されました。")
 
-	return embeddingResponse.Data[0].Embedding, nil
-}
+	// ユーザーから質問を受け取り検索を実行
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Println("質問を入力してください:")
+	query, _ := reader.ReadString('\n')
+	query = strings.TrimSpace(query)
 
-func insertFAQ(conn *pgx.Conn, question, answer string) error {
-	embedding, err := getEmbedding(question)
+	result, err := svc.AnswerFAQ(faq.RAGParams{Query: query, Mode: mode})
 	if err != nil {
-		log.Printf("Embedding生成エラー: %v", err)
-		return fmt.Errorf("embedding generation failed: %v", err)
+		fmt.Println("該当するFAQが見つかりませんでした。")
+		return
 	}
 
-	vectorEmbedding := pgvector.NewVector(embedding)
-
-	_, err = conn.Exec(context.Background(), `
-		INSERT INTO faqs (question, answer, embedding)
-		VALUES ($1, $2, $3)
-	`, question, answer, vectorEmbedding)
-
-	if err != nil {
-		log.Printf("FAQデータの登録エラー: %v", err)
-		return err
+	fmt.Printf("A: %s\n", result.Answer)
+	if len(result.Citations) > 0 {
+		fmt.Printf("参照FAQ ID: %v\n", result.Citations)
 	}
-
-	log.Printf("FAQデータ登録完了: %s", question)
-	return nil
 }
 
-// FAQを検索する関数
-func searchFAQ(conn *pgx.Conn, query string) {
-	embedding, err := getEmbedding(query)
-	if err != nil {
-		log.Printf("Embedding生成エラー: %v", err)
-		return
+func runServer(svc *faq.QueryService, addr, docsRoot string) {
+	server := faq.NewServer(svc, docsRoot)
+	log.Printf("Listening on %s", addr)
+	if err := http.ListenAndServe(addr, server.Handler()); err != nil {
+		log.Fatalf("Server failed: %v", err)
 	}
-
-	vectorEmbedding := pgvector.NewVector(embedding)
-
-	// 一番近いFAQを取得
-	var question, answer string
-	err = conn.QueryRow(context.Background(), `
-		SELECT question, answer
-		FROM faqs
-		ORDER BY embedding <-> $1
-		LIMIT 1
-	`, vectorEmbedding).Scan(&question, &answer)
-
-	if err != nil {
-		fmt.Println("該当するFAQが見つかりませんでした。")
-		return
-	}
-
-	fmt.Printf("Q: %s\nA: %s\n", question, answer)
 }
 
-func loadFAQsFromFile(filename string) ([]struct {
-	Question string `json:"question"`
-	Answer   string `json:"answer"`
-}, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// runMigrate handles the `rag-app migrate up|down|status` subcommand: up
+// applies all pending migrations, down rolls back the most recently applied
+// one, and status lists applied/pending versions.
+func runMigrate(args []string) {
+	if len(args) != 1 {
+		log.Fatalf("usage: rag-app migrate up|down|status")
 	}
-	defer file.Close()
 
-	var faqs []struct {
-		Question string `json:"question"`
-		Answer   string `json:"answer"`
+	if err := godotenv.Load(); err != nil {
+		log.Fatalf("Error loading .env file: %v", err)
 	}
-	err = json.NewDecoder(file).Decode(&faqs)
-	return faqs, err
-}
 
-func getExistingQuestions(conn *pgx.Conn) (map[string]bool, error) {
-	rows, err := conn.Query(context.Background(), `SELECT question FROM faqs`)
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, postgresURL())
 	if err != nil {
-		return nil, fmt.Errorf("Failed to fetch existing questions: %v", err)
+		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer rows.Close()
+	defer pool.Close()
 
-	existingQuestions := make(map[string]bool)
-	for rows.Next() {
-		var question string
-		if err := rows.Scan(&question); err != nil {
-			return nil, fmt.Errorf("Error scanning question: %v", err)
+	runner := migrate.NewRunner(pool, migrate.IndexParamsFromEnv())
+
+	switch args[0] {
+	case "up":
+		if err := runner.Up(ctx); err != nil {
+			log.Fatalf("migrate up failed: %v", err)
+		}
+		fmt.Println("migrations applied")
+	case "down":
+		if err := runner.Down(ctx); err != nil {
+			log.Fatalf("migrate down failed: %v", err)
 		}
-		existingQuestions[question] = true
+		fmt.Println("migration rolled back")
+	case "status":
+		applied, pending, err := runner.Status(ctx)
+		if err != nil {
+			log.Fatalf("migrate status failed: %v", err)
+		}
+		fmt.Printf("applied: %v\npending: %v\n", applied, pending)
+	default:
+		log.Fatalf("unknown migrate subcommand: %s (want up, down, or status)", args[0])
 	}
-
-	return existingQuestions, nil
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	mode := flag.String("mode", "cli", "run mode: server or cli")
+	addr := flag.String("addr", ":8080", "address to listen on in server mode")
+	retrievalMode := flag.String("retrieval", "dense", "retrieval mode in cli mode: dense, sparse, or hybrid")
+	flag.Parse()
 
 	//.env ファイルを読み込む
 	err := godotenv.Load()
@@ -161,47 +126,46 @@ func main() {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
-	url := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", os.Getenv("POSTGRES_USER"),
-		os.Getenv("POSTGRES_PW"), os.Getenv("POSTGRES_HOST"), os.Getenv("POSTGRES_PORT"), os.Getenv("POSTGRES_DB"))
-
-	conn, err := pgx.Connect(context.Background(), url)
+	pool, err := pgxpool.New(context.Background(), postgresURL())
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer conn.Close(context.Background())
+	defer pool.Close()
 
-	// データベース内の既存の質問を取得
-	existingQuestions, err := getExistingQuestions(conn)
+	embedder, err := faq.NewEmbedderFromEnv()
 	if err != nil {
-		log.Fatalf("Error getting existing questions: %v", err)
+		log.Fatalf("Failed to configure embedding provider: %v", err)
 	}
-	// JSON から FAQ をロード
-	faqs, err := loadFAQsFromFile("faqs.json")
+
+	reranker, err := faq.NewRerankerFromEnv()
 	if err != nil {
-		log.Fatalf("Error loading FAQs: %v", err)
+		log.Fatalf("Failed to configure rerank provider: %v", err)
 	}
 
-	// 新しい質問のみ登録
-	for _, faq := range faqs {
-		if _, exists := existingQuestions[faq.Question]; exists {
-			log.Printf("既存の質問です。スキップ: %s", faq.Question)
-			continue
-		}
-
-		err = insertFAQ(conn, faq.Question, faq.Answer)
-		if err != nil {
-			log.Printf("Error inserting FAQ: %v", err)
-            return
-		} 
+	answerer, err := faq.NewAnswererFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to configure answer provider: %v", err)
 	}
 
-	fmt.Println("全てのFAQデータが登録されました。")
+	svc := faq.NewQueryService(pool, embedder,
+		faq.WithBatchSize(envInt("EMBED_BATCH_SIZE")),
+		faq.WithConcurrency(envInt("EMBED_CONCURRENCY")),
+		faq.WithReranker(reranker),
+		faq.WithAnswerer(answerer),
+		faq.WithChunkParams(faq.ChunkParams{Size: envInt("CHUNK_SIZE"), Overlap: envInt("CHUNK_OVERLAP")}),
+		faq.WithFTSConfig(os.Getenv("FTS_CONFIG")),
+	)
 
-	// ユーザーから質問を受け取り検索を実行
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("質問を入力してください:")
-	query, _ := reader.ReadString('\n')
-	query = strings.TrimSpace(query)
-
-	searchFAQ(conn, query)
+	switch *mode {
+	case "cli":
+		rMode, err := retrieval.ParseMode(*retrievalMode)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		runCLI(svc, rMode)
+	case "server":
+		runServer(svc, *addr, os.Getenv("DOCS_ROOT"))
+	default:
+		log.Fatalf("unknown mode: %s (want server or cli)", *mode)
+	}
 }